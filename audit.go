@@ -0,0 +1,11 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+)
+
+// auditLog emits structured (JSON) audit events for CRL generations and
+// revocation list parse warnings, so operators can correlate compliance-
+// relevant events without grepping plain-text logs.
+var auditLog = slog.New(slog.NewJSONHandler(os.Stdout, nil))
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultBaseRefreshInterval is how many full CRL generations elapse before
+// the delta base is rotated, when BASE_REFRESH_INTERVAL is unset.
+const defaultBaseRefreshInterval = 10
+
+const baseRefreshIntervalEnv = "BASE_REFRESH_INTERVAL"
+
+// publicBaseURLEnv names the env var giving the externally reachable base
+// URL (scheme + host, no trailing slash) this server is served behind, used
+// to build an absolute Freshest CRL distribution point URI. Left unset, the
+// URI falls back to a path-only (relative) form, which RFC 5280 discourages
+// for GeneralName URIs and which a client holding only the CRL bytes cannot
+// resolve.
+const publicBaseURLEnv = "PUBLIC_BASE_URL"
+
+var (
+	// oidExtensionDeltaCRLIndicator is the Delta CRL Indicator extension
+	// (RFC 5280 5.2.4), carrying the CRL number of the base full CRL.
+	oidExtensionDeltaCRLIndicator = asn1.ObjectIdentifier{2, 5, 29, 27}
+	// oidExtensionFreshestCRL is the Freshest CRL extension (RFC 5280
+	// 5.2.6), pointing relying parties at the delta CRL distribution point.
+	oidExtensionFreshestCRL = asn1.ObjectIdentifier{2, 5, 29, 46}
+)
+
+// distributionPoint and distributionPointName mirror the ASN.1 structures of
+// RFC 5280 4.2.1.13/4.2.1.15, which are shared by the CRLDistributionPoints
+// and FreshestCRL extensions.
+type distributionPoint struct {
+	DistributionPoint distributionPointName `asn1:"optional,tag:0"`
+}
+
+type distributionPointName struct {
+	FullName []asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+func baseRefreshIntervalFromEnv() int {
+	raw := os.Getenv(baseRefreshIntervalEnv)
+	if raw == "" {
+		return defaultBaseRefreshInterval
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid %s value %q, using default of %d", baseRefreshIntervalEnv, raw, defaultBaseRefreshInterval)
+		return defaultBaseRefreshInterval
+	}
+
+	return n
+}
+
+// publicBaseURLFromEnv returns PUBLIC_BASE_URL with any trailing slash
+// trimmed, or "" if unset.
+func publicBaseURLFromEnv() string {
+	return strings.TrimRight(os.Getenv(publicBaseURLEnv), "/")
+}
+
+// deltaCRLURI returns the Freshest CRL distribution point URI for this
+// issuer: an absolute URL when publicBaseURL is configured, otherwise the
+// path-only form used historically (and logged as non-conformant, since a
+// client that only has the CRL bytes can't resolve a relative URI).
+func (s *CRLServer) deltaCRLURI() string {
+	path := fmt.Sprintf("/%s-delta.crl", s.name)
+	if s.publicBaseURL == "" {
+		return path
+	}
+	return s.publicBaseURL + path
+}
+
+// freshestCRLExtension builds a non-critical Freshest CRL extension with a
+// single HTTP(S) distribution point URI.
+func freshestCRLExtension(uri string) (pkix.Extension, error) {
+	value, err := marshalDistributionPointURI(uri)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{
+		Id:       oidExtensionFreshestCRL,
+		Critical: false,
+		Value:    value,
+	}, nil
+}
+
+// baseCRLNumberExtension builds the critical Delta CRL Indicator extension
+// carrying the CRL number of the base full CRL this delta is relative to.
+func baseCRLNumberExtension(baseNumber *big.Int) (pkix.Extension, error) {
+	value, err := asn1.Marshal(baseNumber)
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("marshaling base CRL number: %w", err)
+	}
+
+	return pkix.Extension{
+		Id:       oidExtensionDeltaCRLIndicator,
+		Critical: true,
+		Value:    value,
+	}, nil
+}
+
+func marshalDistributionPointURI(uri string) ([]byte, error) {
+	generalName := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte(uri)}
+	dp := distributionPoint{
+		DistributionPoint: distributionPointName{
+			FullName: []asn1.RawValue{generalName},
+		},
+	}
+
+	return asn1.Marshal([]distributionPoint{dp})
+}
+
+// diffRevokedCerts compares a base revoked-certificate snapshot against the
+// current one and returns the certificates that were added and removed
+// (i.e. no longer revoked) since the base.
+func diffRevokedCerts(base, current []RevokedCert) (added, removed []RevokedCert) {
+	baseBySerial := make(map[string]RevokedCert, len(base))
+	for _, rc := range base {
+		baseBySerial[rc.SerialNumber.String()] = rc
+	}
+
+	currentBySerial := make(map[string]RevokedCert, len(current))
+	for _, rc := range current {
+		currentBySerial[rc.SerialNumber.String()] = rc
+		if _, ok := baseBySerial[rc.SerialNumber.String()]; !ok {
+			added = append(added, rc)
+		}
+	}
+
+	for serial, rc := range baseBySerial {
+		if _, ok := currentBySerial[serial]; !ok {
+			removed = append(removed, rc)
+		}
+	}
+
+	return added, removed
+}
+
+// handleDeltaCRL serves the RFC 5280 delta CRL relative to the current base.
+func (s *CRLServer) handleDeltaCRL(w http.ResponseWriter, r *http.Request) {
+	delta, err := s.getDeltaCRL()
+	if err != nil {
+		log.Printf("Error generating delta CRL: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pkix-crl")
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(cacheDuration.Seconds())))
+	w.WriteHeader(http.StatusOK)
+	w.Write(delta)
+}
+
+func (s *CRLServer) getDeltaCRL() ([]byte, error) {
+	s.mu.RLock()
+	if s.cachedDeltaCRL != nil && time.Since(s.deltaCacheTime) < cacheDuration {
+		defer s.mu.RUnlock()
+		return s.cachedDeltaCRL, nil
+	}
+	s.mu.RUnlock()
+
+	// Make sure a full CRL (and therefore a base snapshot) exists first.
+	if _, err := s.getCRL(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cachedDeltaCRL != nil && time.Since(s.deltaCacheTime) < cacheDuration {
+		return s.cachedDeltaCRL, nil
+	}
+
+	if s.baseCRLNumber == nil {
+		return nil, fmt.Errorf("no base CRL available yet")
+	}
+
+	caCert, caPrivKey, err := loadCertAndKey(s.crtFile, s.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading certificate and key: %w", err)
+	}
+
+	revokedCerts, err := s.loadRevokedCertificates()
+	if err != nil {
+		return nil, fmt.Errorf("loading revoked certificates: %w", err)
+	}
+
+	added, removed := diffRevokedCerts(s.baseRevokedCerts, revokedCerts)
+
+	now := time.Now()
+	deltaNumber, err := s.nextCRLNumber()
+	if err != nil {
+		return nil, fmt.Errorf("allocating delta CRL number: %w", err)
+	}
+
+	baseCRLNumber, err := baseCRLNumberExtension(s.baseCRLNumber)
+	if err != nil {
+		return nil, fmt.Errorf("building delta CRL indicator extension: %w", err)
+	}
+
+	template := &x509.RevocationList{
+		Number:          deltaNumber,
+		ThisUpdate:      now,
+		NextUpdate:      now.Add(cacheDuration),
+		ExtraExtensions: []pkix.Extension{baseCRLNumber},
+	}
+
+	for _, rc := range added {
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   rc.SerialNumber,
+			RevocationTime: rc.RevocationTime,
+			ReasonCode:     rc.Reason,
+		})
+	}
+
+	// Removed (no-longer-revoked) certificates are carried in the delta with
+	// reason code 8 (removeFromCRL), per RFC 5280 5.2.4, so clients merging
+	// base and delta drop them from the effective revoked set.
+	for _, rc := range removed {
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   rc.SerialNumber,
+			RevocationTime: now,
+			ReasonCode:     removeFromCRLReasonCode,
+		})
+	}
+
+	deltaBytes, err := x509.CreateRevocationList(rand.Reader, template, caCert, caPrivKey)
+	if err != nil {
+		return nil, fmt.Errorf("creating delta CRL: %w", err)
+	}
+
+	s.cachedDeltaCRL = deltaBytes
+	s.deltaCacheTime = now
+
+	log.Printf("Generated new delta CRL with number %s (base %s, %d added, %d removed)",
+		deltaNumber.String(), s.baseCRLNumber.String(), len(added), len(removed))
+
+	return deltaBytes, nil
+}
+
+// removeFromCRLReasonCode is the RFC 5280 reason code used to mark a
+// certificate as removed from a delta CRL (it is no longer revoked).
+const removeFromCRLReasonCode = 8
+
+// baseSnapshotFile is the name, relative to the issuer's cacheDir, of the
+// persisted delta base snapshot.
+const baseSnapshotFile = "delta-base.json"
+
+type persistedRevokedCert struct {
+	Serial string `json:"serial"`
+	Time   int64  `json:"time"`
+	Reason int    `json:"reason"`
+}
+
+type baseSnapshot struct {
+	Number  string                 `json:"number"`
+	Revoked []persistedRevokedCert `json:"revoked"`
+}
+
+// saveBaseSnapshot persists the current delta base (CRL number and revoked
+// set) to the issuer's cacheDir so it survives a restart.
+func (s *CRLServer) saveBaseSnapshot() error {
+	snapshot := baseSnapshot{
+		Number: s.baseCRLNumber.String(),
+	}
+	for _, rc := range s.baseRevokedCerts {
+		snapshot.Revoked = append(snapshot.Revoked, persistedRevokedCert{
+			Serial: rc.SerialNumber.Text(16),
+			Time:   rc.RevocationTime.Unix(),
+			Reason: rc.Reason,
+		})
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("marshaling base snapshot: %w", err)
+	}
+
+	return os.WriteFile(filepath.Join(s.cacheDir, baseSnapshotFile), data, 0644)
+}
+
+// loadBaseSnapshot restores the delta base persisted by saveBaseSnapshot, if
+// any, so deltas remain valid across restarts.
+func (s *CRLServer) loadBaseSnapshot() {
+	data, err := os.ReadFile(filepath.Join(s.cacheDir, baseSnapshotFile))
+	if err != nil {
+		return
+	}
+
+	var snapshot baseSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		log.Printf("Warning: failed to parse delta base snapshot: %v", err)
+		return
+	}
+
+	number := new(big.Int)
+	if _, ok := number.SetString(snapshot.Number, 10); !ok {
+		log.Printf("Warning: invalid base CRL number in snapshot: %s", snapshot.Number)
+		return
+	}
+
+	revoked := make([]RevokedCert, 0, len(snapshot.Revoked))
+	for _, rc := range snapshot.Revoked {
+		serial := new(big.Int)
+		if _, ok := serial.SetString(rc.Serial, 16); !ok {
+			continue
+		}
+		revoked = append(revoked, RevokedCert{
+			SerialNumber:   serial,
+			RevocationTime: time.Unix(rc.Time, 0),
+			Reason:         rc.Reason,
+		})
+	}
+
+	s.mu.Lock()
+	s.baseCRLNumber = number
+	s.baseRevokedCerts = revoked
+	s.mu.Unlock()
+
+	log.Printf("Loaded delta base snapshot with CRL number %s (%d revoked)", number.String(), len(revoked))
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestIssuer writes a self-signed CA certificate, PKCS8 key and plain-
+// format revocation list under a fresh temp directory, and returns a
+// CRLServer ready to generate CRLs for it.
+func newTestIssuer(t *testing.T, revoked map[string]int) *CRLServer {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("marshaling key: %v", err)
+	}
+
+	dir := t.TempDir()
+	crtFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	listFile := filepath.Join(dir, "list.txt")
+	cacheDir := filepath.Join(dir, "cache")
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		t.Fatalf("mkdir cache dir: %v", err)
+	}
+
+	if err := os.WriteFile(crtFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0644); err != nil {
+		t.Fatalf("writing cert: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0644); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+	if err := writeTestRevocationList(listFile, revoked); err != nil {
+		t.Fatalf("writing revocation list: %v", err)
+	}
+
+	return &CRLServer{
+		name:                "test-issuer",
+		crtFile:             crtFile,
+		keyFile:             keyFile,
+		listFile:            listFile,
+		cacheDir:            cacheDir,
+		baseRefreshInterval: defaultBaseRefreshInterval,
+		ocspCache:           make(map[string]ocspCacheEntry),
+	}
+}
+
+// writeTestRevocationList writes a plain-format ("serial:epoch:reason")
+// revocation list with the given serial (hex) -> reason code entries.
+func writeTestRevocationList(path string, revoked map[string]int) error {
+	content := ""
+	now := time.Now().Unix()
+	for serial, reason := range revoked {
+		content += fmt.Sprintf("%s:%d:%d\n", serial, now, reason)
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+func revokedSerialSet(t *testing.T, der []byte) map[string]bool {
+	t.Helper()
+
+	list, err := x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatalf("parsing CRL: %v", err)
+	}
+
+	set := make(map[string]bool, len(list.RevokedCertificateEntries))
+	for _, entry := range list.RevokedCertificateEntries {
+		set[entry.SerialNumber.Text(16)] = true
+	}
+	return set
+}
+
+// TestDeltaCRLReconstructsFullCRL verifies that a client merging the base
+// full CRL's revoked set with the delta CRL (additions, and removals via
+// reason code 8) ends up with the same revoked set as regenerating the full
+// CRL from the current revocation list.
+func TestDeltaCRLReconstructsFullCRL(t *testing.T) {
+	s := newTestIssuer(t, map[string]int{
+		"a": 0,
+		"b": 1,
+		"c": 4,
+	})
+
+	baseDER, err := s.regenerateCRL()
+	if err != nil {
+		t.Fatalf("regenerateCRL (base): %v", err)
+	}
+	baseSet := revokedSerialSet(t, baseDER)
+	if want := map[string]bool{"a": true, "b": true, "c": true}; !mapsEqual(baseSet, want) {
+		t.Fatalf("base revoked set = %v, want %v", baseSet, want)
+	}
+
+	// "b" is no longer revoked, "d" is newly revoked.
+	if err := writeTestRevocationList(s.listFile, map[string]int{
+		"a": 0,
+		"c": 4,
+		"d": 2,
+	}); err != nil {
+		t.Fatalf("rewriting revocation list: %v", err)
+	}
+
+	deltaDER, err := s.getDeltaCRL()
+	if err != nil {
+		t.Fatalf("getDeltaCRL: %v", err)
+	}
+
+	deltaList, err := x509.ParseRevocationList(deltaDER)
+	if err != nil {
+		t.Fatalf("parsing delta CRL: %v", err)
+	}
+
+	reconstructed := make(map[string]bool, len(baseSet))
+	for k := range baseSet {
+		reconstructed[k] = true
+	}
+	for _, entry := range deltaList.RevokedCertificateEntries {
+		serial := entry.SerialNumber.Text(16)
+		if entry.ReasonCode == removeFromCRLReasonCode {
+			delete(reconstructed, serial)
+		} else {
+			reconstructed[serial] = true
+		}
+	}
+
+	fullDER, err := s.regenerateCRL()
+	if err != nil {
+		t.Fatalf("regenerateCRL (updated): %v", err)
+	}
+	fullSet := revokedSerialSet(t, fullDER)
+
+	if !mapsEqual(reconstructed, fullSet) {
+		t.Fatalf("base ∪ delta = %v, want %v (full CRL)", reconstructed, fullSet)
+	}
+	if want := map[string]bool{"a": true, "c": true, "d": true}; !mapsEqual(fullSet, want) {
+		t.Fatalf("full CRL revoked set = %v, want %v", fullSet, want)
+	}
+}
+
+// TestDeltaAndFullCRLNumbersShareSequence verifies the delta and full CRL
+// numbers are drawn from the same strictly-increasing per-issuer sequence,
+// rather than the delta using a wall-clock-derived number.
+func TestDeltaAndFullCRLNumbersShareSequence(t *testing.T) {
+	s := newTestIssuer(t, map[string]int{"a": 0})
+
+	if _, err := s.regenerateCRL(); err != nil {
+		t.Fatalf("regenerateCRL: %v", err)
+	}
+	fullNumber := new(big.Int).Set(s.cacheNumber)
+
+	deltaDER, err := s.getDeltaCRL()
+	if err != nil {
+		t.Fatalf("getDeltaCRL: %v", err)
+	}
+	deltaList, err := x509.ParseRevocationList(deltaDER)
+	if err != nil {
+		t.Fatalf("parsing delta CRL: %v", err)
+	}
+
+	if deltaList.Number.Cmp(fullNumber) <= 0 {
+		t.Fatalf("delta CRL number %s is not greater than full CRL number %s", deltaList.Number, fullNumber)
+	}
+
+	next, err := s.nextCRLNumber()
+	if err != nil {
+		t.Fatalf("nextCRLNumber: %v", err)
+	}
+	if next.Cmp(deltaList.Number) <= 0 {
+		t.Fatalf("counter did not advance past the delta CRL number: next=%s delta=%s", next, deltaList.Number)
+	}
+}
+
+func mapsEqual(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if !b[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// handleHealthz reports whether every issuer's certificate and key can be
+// loaded, for a Kubernetes liveness probe.
+func handleHealthz(issuers map[string]*CRLServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for name, s := range issuers {
+			if _, _, err := loadCertAndKey(s.crtFile, s.keyFile); err != nil {
+				http.Error(w, fmt.Sprintf("issuer %q: certificate/key not loadable: %v", name, err), http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}
+}
+
+// handleReadyz additionally requires every issuer's revocation list to
+// parse, for a Kubernetes readiness probe.
+func handleReadyz(issuers map[string]*CRLServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for name, s := range issuers {
+			if _, _, err := loadCertAndKey(s.crtFile, s.keyFile); err != nil {
+				http.Error(w, fmt.Sprintf("issuer %q: certificate/key not loadable: %v", name, err), http.StatusServiceUnavailable)
+				return
+			}
+			if _, err := s.loadRevokedCertificates(); err != nil {
+				http.Error(w, fmt.Sprintf("issuer %q: revocation list not parseable: %v", name, err), http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseIndexTxtRevokedLine(t *testing.T) {
+	cases := []struct {
+		name       string
+		fields     []string
+		wantOK     bool
+		wantSerial string
+		wantReason int
+		wantTime   time.Time
+	}{
+		{
+			name:       "revoked with reason suffix",
+			fields:     []string{"R", "250101000000Z", "230601120000Z,keyCompromise", "1A2B3C", "unknown", "/CN=foo"},
+			wantOK:     true,
+			wantSerial: "1A2B3C",
+			wantReason: 1,
+			wantTime:   time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "revoked without reason suffix defaults to unspecified",
+			fields:     []string{"R", "250101000000Z", "230601120000Z", "FF", "unknown", "/CN=bar"},
+			wantOK:     true,
+			wantSerial: "FF",
+			wantReason: 0,
+			wantTime:   time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "removeFromCRL reason maps to code 8",
+			fields:     []string{"R", "250101000000Z", "230601120000Z,removeFromCRL", "01", "unknown", "/CN=baz"},
+			wantOK:     true,
+			wantSerial: "1",
+			wantReason: 8,
+			wantTime:   time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "empty revocation field",
+			fields: []string{"R", "250101000000Z", "", "01", "unknown", "/CN=baz"},
+			wantOK: false,
+		},
+		{
+			name:   "unknown reason name",
+			fields: []string{"R", "250101000000Z", "230601120000Z,bogusReason", "01", "unknown", "/CN=baz"},
+			wantOK: false,
+		},
+		{
+			name:   "malformed timestamp",
+			fields: []string{"R", "250101000000Z", "not-a-timestamp", "01", "unknown", "/CN=baz"},
+			wantOK: false,
+		},
+		{
+			name:   "malformed serial",
+			fields: []string{"R", "250101000000Z", "230601120000Z", "not-hex!!", "unknown", "/CN=baz"},
+			wantOK: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rc, ok := parseIndexTxtRevokedLine(tc.fields)
+			if ok != tc.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tc.wantOK)
+			}
+			if !tc.wantOK {
+				return
+			}
+			if rc.SerialNumber.Text(16) != normalizeHex(tc.wantSerial) {
+				t.Errorf("serial = %s, want %s", rc.SerialNumber.Text(16), tc.wantSerial)
+			}
+			if rc.Reason != tc.wantReason {
+				t.Errorf("reason = %d, want %d", rc.Reason, tc.wantReason)
+			}
+			if !rc.RevocationTime.Equal(tc.wantTime) {
+				t.Errorf("revocationTime = %v, want %v", rc.RevocationTime, tc.wantTime)
+			}
+		})
+	}
+}
+
+// normalizeHex lower-cases a hex literal so it can be compared against
+// big.Int.Text(16), which always returns lowercase digits.
+func normalizeHex(s string) string {
+	out := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		out[i] = c
+	}
+	return string(out)
+}
+
+func TestLoadIndexTxtRevokedCertificates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.txt")
+
+	content := "" +
+		"V\t250101000000Z\t\t01\tunknown\t/CN=valid\n" +
+		"R\t250101000000Z\t230601120000Z,keyCompromise\t02\tunknown\t/CN=revoked-with-reason\n" +
+		"R\t250101000000Z\t230602120000Z\t03\tunknown\t/CN=revoked-no-reason\n" +
+		"E\t250101000000Z\t\t04\tunknown\t/CN=expired\n" +
+		"\n" +
+		"this line is too short\n" +
+		"R\tonly\tthree\n" +
+		"R\t250101000000Z\tnot-a-timestamp\t05\tunknown\t/CN=bad-time\n"
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	revoked, err := loadIndexTxtRevokedCertificates("test-issuer", path)
+	if err != nil {
+		t.Fatalf("loadIndexTxtRevokedCertificates: %v", err)
+	}
+
+	if len(revoked) != 2 {
+		t.Fatalf("got %d revoked certs, want 2: %+v", len(revoked), revoked)
+	}
+
+	if revoked[0].SerialNumber.Text(16) != "2" || revoked[0].Reason != 1 {
+		t.Errorf("revoked[0] = %+v, want serial 02 reason 1", revoked[0])
+	}
+	if revoked[1].SerialNumber.Text(16) != "3" || revoked[1].Reason != 0 {
+		t.Errorf("revoked[1] = %+v, want serial 03 reason 0", revoked[1])
+	}
+}
+
+func TestLoadIndexTxtRevokedCertificatesMissingFile(t *testing.T) {
+	revoked, err := loadIndexTxtRevokedCertificates("test-issuer", filepath.Join(t.TempDir(), "does-not-exist.txt"))
+	if err != nil {
+		t.Fatalf("expected no error for a missing file, got %v", err)
+	}
+	if len(revoked) != 0 {
+		t.Fatalf("expected no revoked certs, got %d", len(revoked))
+	}
+}
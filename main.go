@@ -2,20 +2,27 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"crypto"
 	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
 	"log"
 	"math/big"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
@@ -24,7 +31,6 @@ const (
 
 var (
 	cacheDir = filepath.Join(".", "temp")
-	tlsDir   = filepath.Join(".", "tls")
 	confDir  = filepath.Join(".", "conf")
 )
 
@@ -34,51 +40,227 @@ type RevokedCert struct {
 	Reason         int
 }
 
+// CRLServer generates and serves the CRL (and delta CRL, and OCSP
+// responses) for a single issuer. A deployment runs one per issuer,
+// discovered from confDir by discoverIssuers.
 type CRLServer struct {
+	// name is the issuer's short name, taken from its confDir subdirectory,
+	// used to build its endpoints and per-issuer cache directory.
+	name string
+
 	crtFile  string
 	keyFile  string
 	listFile string
 
+	// cacheDir is this issuer's own cache directory (cacheDir/{name}).
+	cacheDir string
+
+	// ocspCrtFile and ocspKeyFile, when set, point at a dedicated OCSP
+	// signing certificate/key instead of reusing the CA's own.
+	ocspCrtFile string
+	ocspKeyFile string
+
+	// baseRefreshInterval is the number of full CRL generations after which
+	// the delta base is rotated, keeping delta CRLs small.
+	baseRefreshInterval int
+
+	// publicBaseURL, when set, is the externally reachable scheme+host this
+	// server is served behind, used to build an absolute Freshest CRL
+	// distribution point URI. See deltaCRLURI.
+	publicBaseURL string
+
 	mu          sync.RWMutex
 	cachedCRL   []byte
 	cacheTime   time.Time
 	cacheNumber *big.Int
+
+	cachedDeltaCRL []byte
+	deltaCacheTime time.Time
+
+	baseCRLNumber    *big.Int
+	baseRevokedCerts []RevokedCert
+	generationCount  int
+
+	// previousRevokedCerts is the revoked set as of the last regeneration,
+	// used to compute the added/removed diff reported to the revocation
+	// webhook.
+	previousRevokedCerts []RevokedCert
+
+	ocspMu    sync.RWMutex
+	ocspCache map[string]ocspCacheEntry
 }
 
 func main() {
-	// Ensure temp directory exists
+	// Ensure the top-level cache directory exists
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		log.Fatalf("Failed to create cache directory: %v", err)
 	}
 
-	// Define file paths
-	crtFile := filepath.Join(tlsDir, "tls.crt")
-	keyFile := filepath.Join(tlsDir, "tls.key")
-	listFile := filepath.Join(confDir, "list.txt")
-
-	// Validate certificate and key can be loaded (initial check)
-	_, _, err := loadCertAndKey(crtFile, keyFile)
+	names, err := discoverIssuers(confDir)
 	if err != nil {
-		log.Fatalf("Failed to load certificate and key: %v", err)
+		log.Fatalf("Failed to discover issuers: %v", err)
+	}
+	if len(names) == 0 {
+		log.Fatalf("No issuers found under %s (expected subdirectories with tls.crt, tls.key, and list.txt or index.txt)", confDir)
 	}
-	log.Println("Certificate and key validated successfully")
 
-	server := &CRLServer{
-		crtFile:  crtFile,
-		keyFile:  keyFile,
-		listFile: listFile,
+	if publicBaseURLFromEnv() == "" {
+		log.Printf("Warning: %s is not set; the Freshest CRL distribution point will be a relative URI, which RFC 5280 discourages and which a client holding only the CRL bytes cannot resolve", publicBaseURLEnv)
+	}
+
+	issuers := make(map[string]*CRLServer, len(names))
+	for _, name := range names {
+		issuerDir := filepath.Join(confDir, name)
+		issuerCacheDir := filepath.Join(cacheDir, name)
+
+		if err := os.MkdirAll(issuerCacheDir, 0755); err != nil {
+			log.Fatalf("Failed to create cache directory for issuer %q: %v", name, err)
+		}
+
+		server := &CRLServer{
+			name:                name,
+			crtFile:             filepath.Join(issuerDir, "tls.crt"),
+			keyFile:             filepath.Join(issuerDir, "tls.key"),
+			listFile:            issuerListFile(issuerDir),
+			cacheDir:            issuerCacheDir,
+			ocspCrtFile:         os.Getenv(ocspCrtFileEnv),
+			ocspKeyFile:         os.Getenv(ocspKeyFileEnv),
+			baseRefreshInterval: baseRefreshIntervalFromEnv(),
+			publicBaseURL:       publicBaseURLFromEnv(),
+			ocspCache:           make(map[string]ocspCacheEntry),
+		}
+
+		// Validate certificate and key can be loaded (initial check)
+		if _, _, err := loadCertAndKey(server.crtFile, server.keyFile); err != nil {
+			log.Fatalf("Failed to load certificate and key for issuer %q: %v", name, err)
+		}
+
+		// Try to load cached CRL
+		server.loadCachedCRL()
+		server.loadBaseSnapshot()
+		server.loadPreviousRevokedCerts()
+
+		// Make sure a CRL is ready before serving, then keep it fresh via
+		// the file watcher instead of waiting for the cache to expire.
+		if server.cachedCRL == nil || time.Since(server.cacheTime) >= cacheDuration {
+			if _, err := server.regenerateCRL(); err != nil {
+				log.Fatalf("Failed to generate initial CRL for issuer %q: %v", name, err)
+			}
+		}
+		startFileWatcher(server)
+
+		http.HandleFunc(fmt.Sprintf("/%s.crl", name), server.handleCRL)
+		http.HandleFunc(fmt.Sprintf("/%s-delta.crl", name), server.handleDeltaCRL)
+		http.HandleFunc(fmt.Sprintf("/%s/ocsp", name), server.handleOCSP)
+		http.HandleFunc(fmt.Sprintf("/%s/ocsp/", name), server.handleOCSP)
+
+		issuers[name] = server
+		log.Printf("Registered issuer %q (CRL: /%s.crl, delta: /%s-delta.crl, OCSP: /%s/ocsp)", name, name, name, name)
 	}
 
-	// Try to load cached CRL
-	server.loadCachedCRL()
+	http.HandleFunc("/", handleIndex(issuers))
+	http.Handle("/metrics", promhttp.Handler())
+	http.HandleFunc("/healthz", handleHealthz(issuers))
+	http.HandleFunc("/readyz", handleReadyz(issuers))
 
-	// Set up HTTP handler
-	http.HandleFunc("/", server.handleCRL)
+	srv := &http.Server{Addr: ":8080"}
 
 	log.Println("Starting CRL server on :8080")
-	log.Println("Hot-reload enabled: certificates and revocation list will be reloaded on each CRL generation")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		log.Fatalf("Server failed: %v", err)
+	log.Println("Hot-reload enabled: certificates and revocation lists are watched and regenerate the CRL immediately on change")
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	log.Println("Shutting down...")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Warning: server shutdown did not complete cleanly: %v", err)
+	}
+	closeSigners()
+}
+
+// discoverIssuers scans confDir for subdirectories that look like a
+// complete issuer (containing tls.crt, tls.key, and either list.txt or an
+// OpenSSL index.txt revocation list), returning their names in sorted order.
+func discoverIssuers(confDir string) ([]string, error) {
+	entries, err := os.ReadDir(confDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading conf directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		issuerDir := filepath.Join(confDir, entry.Name())
+		required := []string{"tls.crt", "tls.key"}
+		complete := true
+		for _, f := range required {
+			if _, err := os.Stat(filepath.Join(issuerDir, f)); err != nil {
+				complete = false
+				break
+			}
+		}
+		if !complete {
+			continue
+		}
+		if _, err := os.Stat(issuerListFile(issuerDir)); err != nil {
+			continue
+		}
+
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// issuerListFile resolves the revocation list file for an issuer directory,
+// preferring the server's native "list.txt" and falling back to an OpenSSL
+// `index.txt` CA database so per-issuer format selection (see
+// detectRevocationFormat) actually has a filename to key off of, instead of
+// every issuer being forced through the one process-wide REVOCATION_FORMAT
+// env var.
+func issuerListFile(issuerDir string) string {
+	listFile := filepath.Join(issuerDir, "list.txt")
+	if _, err := os.Stat(listFile); err == nil {
+		return listFile
+	}
+	return filepath.Join(issuerDir, "index.txt")
+}
+
+// handleIndex serves a simple page at "/" listing every registered issuer
+// and its CRL/delta endpoints.
+func handleIndex(issuers map[string]*CRLServer) http.HandlerFunc {
+	names := make([]string, 0, len(issuers))
+	for name := range issuers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintln(w, "<html><head><title>simple-crl-server</title></head><body>")
+		fmt.Fprintln(w, "<h1>Issuers</h1><ul>")
+		for _, name := range names {
+			fmt.Fprintf(w, "<li>%s: <a href=\"/%s.crl\">CRL</a>, <a href=\"/%s-delta.crl\">delta CRL</a>, <a href=\"/%s/ocsp\">OCSP</a></li>\n",
+				name, name, name, name)
+		}
+		fmt.Fprintln(w, "</ul></body></html>")
 	}
 }
 
@@ -96,47 +278,70 @@ func (s *CRLServer) handleCRL(w http.ResponseWriter, r *http.Request) {
 	w.Write(crl)
 }
 
+// getCRL returns the cached CRL if it is still fresh, otherwise regenerates
+// it. The file watcher started in main regenerates the CRL whenever its
+// input files change; when they do not change, the cached CRL goes stale
+// after cacheDuration and is regenerated here on the next request.
 func (s *CRLServer) getCRL() ([]byte, error) {
 	s.mu.RLock()
-	// Check if cache is still valid
 	if s.cachedCRL != nil && time.Since(s.cacheTime) < cacheDuration {
 		defer s.mu.RUnlock()
+		crlCacheHitsTotal.WithLabelValues(s.name).Inc()
 		return s.cachedCRL, nil
 	}
 	s.mu.RUnlock()
 
-	// Need to generate new CRL
+	return s.regenerateCRL()
+}
+
+// regenerateCRL unconditionally reloads the certificate, key and revocation
+// list and produces a fresh CRL, regardless of cache freshness. It is
+// called both as a fallback from getCRL and directly by the file watcher
+// whenever crtFile, keyFile or listFile change.
+func (s *CRLServer) regenerateCRL() ([]byte, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Double-check after acquiring write lock
-	if s.cachedCRL != nil && time.Since(s.cacheTime) < cacheDuration {
-		return s.cachedCRL, nil
-	}
+	start := time.Now()
 
 	// Load certificate and key (hot-reload for Kubernetes Secret updates)
-	log.Println("Loading certificate and key...")
+	log.Printf("Loading certificate and key for issuer %q...", s.name)
 	caCert, caPrivKey, err := loadCertAndKey(s.crtFile, s.keyFile)
 	if err != nil {
+		crlLoadErrorsTotal.WithLabelValues(s.name, "cert").Inc()
 		return nil, fmt.Errorf("loading certificate and key: %w", err)
 	}
 
 	// Load revoked certificates (hot-reload for ConfigMap/Secret updates)
-	log.Println("Loading revocation list...")
+	log.Printf("Loading revocation list for issuer %q...", s.name)
 	revokedCerts, err := s.loadRevokedCertificates()
 	if err != nil {
+		crlLoadErrorsTotal.WithLabelValues(s.name, "list").Inc()
 		return nil, fmt.Errorf("loading revoked certificates: %w", err)
 	}
-	log.Printf("Loaded %d revoked certificate(s)", len(revokedCerts))
+	log.Printf("Loaded %d revoked certificate(s) for issuer %q", len(revokedCerts), s.name)
+	s.invalidateOCSPCache()
+
+	added, removed := diffRevokedCerts(s.previousRevokedCerts, revokedCerts)
+	s.previousRevokedCerts = revokedCerts
 
 	// Create CRL template
 	now := time.Now()
-	crlNumber := big.NewInt(now.Unix())
+	crlNumber, err := s.nextCRLNumber()
+	if err != nil {
+		return nil, fmt.Errorf("allocating CRL number: %w", err)
+	}
+
+	freshestCRL, err := freshestCRLExtension(s.deltaCRLURI())
+	if err != nil {
+		return nil, fmt.Errorf("building freshest CRL extension: %w", err)
+	}
 
 	template := &x509.RevocationList{
-		Number:     crlNumber,
-		ThisUpdate: now,
-		NextUpdate: now.Add(cacheDuration),
+		Number:          crlNumber,
+		ThisUpdate:      now,
+		NextUpdate:      now.Add(cacheDuration),
+		ExtraExtensions: []pkix.Extension{freshestCRL},
 	}
 
 	// Use RevokedCertificateEntries (new API)
@@ -164,11 +369,67 @@ func (s *CRLServer) getCRL() ([]byte, error) {
 		log.Printf("Warning: failed to save CRL to cache: %v", err)
 	}
 
-	log.Printf("Generated new CRL with number %s", crlNumber.String())
+	// Rotate the delta base periodically so deltas stay small.
+	s.generationCount++
+	if s.baseCRLNumber == nil || s.generationCount >= s.baseRefreshInterval {
+		s.baseCRLNumber = crlNumber
+		s.baseRevokedCerts = revokedCerts
+		s.generationCount = 0
+		s.cachedDeltaCRL = nil
+		if err := s.saveBaseSnapshot(); err != nil {
+			log.Printf("Warning: failed to save delta base snapshot: %v", err)
+		}
+	}
+
+	duration := time.Since(start)
+	log.Printf("Generated new CRL for issuer %q with number %s", s.name, crlNumber.String())
+
+	crlGenerationsTotal.WithLabelValues(s.name).Inc()
+	crlGenerationDuration.WithLabelValues(s.name).Observe(duration.Seconds())
+	crlRevokedCertificates.WithLabelValues(s.name).Set(float64(len(revokedCerts)))
+	crlCurrentNumber.WithLabelValues(s.name).Set(bigIntToFloat64(crlNumber))
+	crlNextUpdateTimestamp.WithLabelValues(s.name).Set(float64(template.NextUpdate.Unix()))
+
+	auditLog.Info("crl generated",
+		"issuer", s.name,
+		"crlNumber", crlNumber.String(),
+		"revokedCertificates", len(revokedCerts),
+		"added", len(added),
+		"removed", len(removed),
+		"durationSeconds", duration.Seconds(),
+	)
+
+	if len(added) > 0 || len(removed) > 0 {
+		go notifyRevocationWebhook(s.name, crlNumber, added, removed)
+	}
+
 	return crlBytes, nil
 }
 
+// revocationFormatEnv selects the format of listFile, overriding the
+// extension-based detection in detectRevocationFormat.
+const revocationFormatEnv = "REVOCATION_FORMAT"
+
+// detectRevocationFormat decides whether listFile should be parsed as the
+// server's native "serial:epoch:reason" format or as an OpenSSL `index.txt`
+// CA database, based on REVOCATION_FORMAT or the file's name.
+func detectRevocationFormat(listFile string) string {
+	if format := strings.ToLower(strings.TrimSpace(os.Getenv(revocationFormatEnv))); format != "" {
+		return format
+	}
+
+	if strings.HasSuffix(strings.ToLower(filepath.Base(listFile)), "index.txt") {
+		return "index"
+	}
+
+	return "plain"
+}
+
 func (s *CRLServer) loadRevokedCertificates() ([]RevokedCert, error) {
+	if detectRevocationFormat(s.listFile) == "index" {
+		return loadIndexTxtRevokedCertificates(s.name, s.listFile)
+	}
+
 	file, err := os.Open(s.listFile)
 	if err != nil {
 		// If file doesn't exist, return empty list
@@ -195,21 +456,21 @@ func (s *CRLServer) loadRevokedCertificates() ([]RevokedCert, error) {
 		// Parse format: [serial_number]:[epoch]:[reason]
 		parts := strings.Split(line, ":")
 		if len(parts) != 3 {
-			log.Printf("Warning: invalid format at line %d: %s", lineNum, line)
+			auditLog.Warn("invalid revocation list line", "issuer", s.name, "line", lineNum, "raw", line)
 			continue
 		}
 
 		// Parse serial number (hex)
 		serial := new(big.Int)
 		if _, ok := serial.SetString(parts[0], 16); !ok {
-			log.Printf("Warning: invalid serial number at line %d: %s", lineNum, parts[0])
+			auditLog.Warn("invalid revocation serial number", "issuer", s.name, "line", lineNum, "serial", parts[0])
 			continue
 		}
 
 		// Parse epoch timestamp
 		epoch, err := strconv.ParseInt(parts[1], 10, 64)
 		if err != nil {
-			log.Printf("Warning: invalid epoch at line %d: %s", lineNum, parts[1])
+			auditLog.Warn("invalid revocation epoch", "issuer", s.name, "line", lineNum, "serial", parts[0], "epoch", parts[1])
 			continue
 		}
 		revocationTime := time.Unix(epoch, 0)
@@ -217,7 +478,7 @@ func (s *CRLServer) loadRevokedCertificates() ([]RevokedCert, error) {
 		// Parse reason code
 		reason, err := strconv.Atoi(parts[2])
 		if err != nil {
-			log.Printf("Warning: invalid reason code at line %d: %s", lineNum, parts[2])
+			auditLog.Warn("invalid revocation reason code", "issuer", s.name, "line", lineNum, "serial", parts[0], "reason", parts[2])
 			continue
 		}
 
@@ -235,14 +496,42 @@ func (s *CRLServer) loadRevokedCertificates() ([]RevokedCert, error) {
 	return revokedCerts, nil
 }
 
+// crlNumberFile is the name, relative to the issuer's cacheDir, of the
+// persisted monotonic CRL number counter.
+const crlNumberFile = "crl-number"
+
+// nextCRLNumber returns a strictly increasing CRL number, per RFC 5280,
+// by incrementing and persisting a counter in cacheDir rather than using
+// the current time (which could otherwise go backwards across a clock
+// adjustment or repeat within the same second across restarts).
+func (s *CRLServer) nextCRLNumber() (*big.Int, error) {
+	counterFile := filepath.Join(s.cacheDir, crlNumberFile)
+
+	current := big.NewInt(0)
+	if data, err := os.ReadFile(counterFile); err == nil {
+		if _, ok := current.SetString(strings.TrimSpace(string(data)), 10); !ok {
+			return nil, fmt.Errorf("parsing persisted CRL number %q", string(data))
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading CRL number counter: %w", err)
+	}
+
+	next := new(big.Int).Add(current, big.NewInt(1))
+	if err := os.WriteFile(counterFile, []byte(next.String()), 0644); err != nil {
+		return nil, fmt.Errorf("persisting CRL number counter: %w", err)
+	}
+
+	return next, nil
+}
+
 func (s *CRLServer) saveCachedCRL() error {
-	cacheFile := filepath.Join(cacheDir, fmt.Sprintf("crl-%s.der", s.cacheNumber.String()))
+	cacheFile := filepath.Join(s.cacheDir, fmt.Sprintf("crl-%s.der", s.cacheNumber.String()))
 	if err := os.WriteFile(cacheFile, s.cachedCRL, 0644); err != nil {
 		return err
 	}
 
 	// Also save metadata
-	metaFile := filepath.Join(cacheDir, fmt.Sprintf("crl-%s.meta", s.cacheNumber.String()))
+	metaFile := filepath.Join(s.cacheDir, fmt.Sprintf("crl-%s.meta", s.cacheNumber.String()))
 	metaContent := fmt.Sprintf("%d\n", s.cacheTime.Unix())
 	if err := os.WriteFile(metaFile, []byte(metaContent), 0644); err != nil {
 		return err
@@ -253,7 +542,7 @@ func (s *CRLServer) saveCachedCRL() error {
 
 func (s *CRLServer) loadCachedCRL() {
 	// Find the latest cached CRL
-	entries, err := os.ReadDir(cacheDir)
+	entries, err := os.ReadDir(s.cacheDir)
 	if err != nil {
 		return
 	}
@@ -283,7 +572,7 @@ func (s *CRLServer) loadCachedCRL() {
 		// Check if it's the latest
 		if latestNumber == nil || number.Cmp(latestNumber) > 0 {
 			// Try to read metadata
-			metaFile := filepath.Join(cacheDir, fmt.Sprintf("crl-%s.meta", numberStr))
+			metaFile := filepath.Join(s.cacheDir, fmt.Sprintf("crl-%s.meta", numberStr))
 			metaContent, err := os.ReadFile(metaFile)
 			if err != nil {
 				continue
@@ -300,7 +589,7 @@ func (s *CRLServer) loadCachedCRL() {
 			if time.Since(cacheTime) < cacheDuration {
 				latestNumber = number
 				latestTime = cacheTime
-				latestFile = filepath.Join(cacheDir, name)
+				latestFile = filepath.Join(s.cacheDir, name)
 			}
 		}
 	}
@@ -318,54 +607,77 @@ func (s *CRLServer) loadCachedCRL() {
 	}
 }
 
-func loadCertAndKey(crtFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
-	// Load certificate
-	certData, err := os.ReadFile(crtFile)
-	if err != nil {
-		return nil, nil, fmt.Errorf("reading cert file: %w", err)
+// loadPreviousRevokedCerts seeds previousRevokedCerts from whatever prior
+// state survived a restart, so the first regenerateCRL afterwards diffs
+// against the real last-known revoked set instead of nil - otherwise every
+// currently-revoked serial would be reported as newly "added" to the
+// revocation webhook even though nothing actually changed. The cached CRL
+// itself, when present and still fresh, is the most precise source; the
+// delta base snapshot is a fallback for when no cached CRL survived.
+func (s *CRLServer) loadPreviousRevokedCerts() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cachedCRL != nil {
+		crl, err := x509.ParseRevocationList(s.cachedCRL)
+		if err != nil {
+			log.Printf("Warning: failed to parse cached CRL for issuer %q, falling back to delta base: %v", s.name, err)
+		} else {
+			revoked := make([]RevokedCert, 0, len(crl.RevokedCertificateEntries))
+			for _, entry := range crl.RevokedCertificateEntries {
+				revoked = append(revoked, RevokedCert{
+					SerialNumber:   entry.SerialNumber,
+					RevocationTime: entry.RevocationTime,
+					Reason:         entry.ReasonCode,
+				})
+			}
+			s.previousRevokedCerts = revoked
+			return
+		}
 	}
 
-	block, _ := pem.Decode(certData)
-	if block == nil {
-		return nil, nil, fmt.Errorf("failed to parse certificate PEM")
+	if s.baseRevokedCerts != nil {
+		s.previousRevokedCerts = s.baseRevokedCerts
 	}
+}
 
-	caCert, err := x509.ParseCertificate(block.Bytes)
+// loadCertAndKey loads the CA certificate from crtFile and obtains the
+// signing key via the Signer backend selected for keyFile (PEM on disk by
+// default, or a PKCS#11 HSM when configured through env vars).
+func loadCertAndKey(crtFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	caCert, err := loadCertificate(crtFile)
 	if err != nil {
-		return nil, nil, fmt.Errorf("parsing certificate: %w", err)
+		return nil, nil, err
 	}
 
-	// Load private key
-	keyData, err := os.ReadFile(keyFile)
+	signer, err := newSigner(keyFile)
 	if err != nil {
-		return nil, nil, fmt.Errorf("reading key file: %w", err)
+		return nil, nil, fmt.Errorf("selecting signer backend: %w", err)
 	}
 
-	block, _ = pem.Decode(keyData)
-	if block == nil {
-		return nil, nil, fmt.Errorf("failed to parse key PEM")
+	caPrivKey, err := signer.Signer()
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading signing key: %w", err)
 	}
 
-	var caPrivKey interface{}
+	return caCert, caPrivKey, nil
+}
 
-	// Try PKCS8 first
-	caPrivKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+func loadCertificate(crtFile string) (*x509.Certificate, error) {
+	certData, err := os.ReadFile(crtFile)
 	if err != nil {
-		// Try PKCS1 RSA
-		caPrivKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
-		if err != nil {
-			// Try EC
-			caPrivKey, err = x509.ParseECPrivateKey(block.Bytes)
-			if err != nil {
-				return nil, nil, fmt.Errorf("parsing key: %w", err)
-			}
-		}
+		return nil, fmt.Errorf("reading cert file: %w", err)
 	}
 
-	signer, ok := caPrivKey.(crypto.Signer)
-	if !ok {
-		return nil, nil, fmt.Errorf("key does not implement crypto.Signer")
+	block, _ := pem.Decode(certData)
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse certificate PEM")
+	}
+
+	caCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing certificate: %w", err)
 	}
 
-	return caCert, signer, nil
+	return caCert, nil
 }
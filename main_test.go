@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscoverIssuersPerIssuerFormat(t *testing.T) {
+	confDir := t.TempDir()
+
+	plainDir := filepath.Join(confDir, "plain-ca")
+	indexDir := filepath.Join(confDir, "index-ca")
+	incompleteDir := filepath.Join(confDir, "incomplete-ca")
+	for _, dir := range []string{plainDir, indexDir, incompleteDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("mkdir %s: %v", dir, err)
+		}
+	}
+
+	writeFile := func(path string) {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+	}
+
+	writeFile(filepath.Join(plainDir, "tls.crt"))
+	writeFile(filepath.Join(plainDir, "tls.key"))
+	writeFile(filepath.Join(plainDir, "list.txt"))
+
+	writeFile(filepath.Join(indexDir, "tls.crt"))
+	writeFile(filepath.Join(indexDir, "tls.key"))
+	writeFile(filepath.Join(indexDir, "index.txt"))
+
+	writeFile(filepath.Join(incompleteDir, "tls.crt"))
+	writeFile(filepath.Join(incompleteDir, "tls.key"))
+
+	names, err := discoverIssuers(confDir)
+	if err != nil {
+		t.Fatalf("discoverIssuers: %v", err)
+	}
+	if got, want := names, []string{"index-ca", "plain-ca"}; !equalStrings(got, want) {
+		t.Fatalf("discoverIssuers names = %v, want %v", got, want)
+	}
+
+	if got, want := issuerListFile(plainDir), filepath.Join(plainDir, "list.txt"); got != want {
+		t.Errorf("issuerListFile(plain) = %s, want %s", got, want)
+	}
+	if got, want := issuerListFile(indexDir), filepath.Join(indexDir, "index.txt"); got != want {
+		t.Errorf("issuerListFile(index) = %s, want %s", got, want)
+	}
+
+	if got, want := detectRevocationFormat(issuerListFile(indexDir)), "index"; got != want {
+		t.Errorf("detectRevocationFormat(index issuer) = %s, want %s", got, want)
+	}
+	if got, want := detectRevocationFormat(issuerListFile(plainDir)), "plain"; got != want {
+		t.Errorf("detectRevocationFormat(plain issuer) = %s, want %s", got, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
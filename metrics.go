@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math/big"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	crlGenerationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "crl_generations_total",
+		Help: "Total number of CRL generations, per issuer.",
+	}, []string{"issuer"})
+
+	crlGenerationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "crl_generation_duration_seconds",
+		Help: "Time spent generating a CRL, per issuer.",
+	}, []string{"issuer"})
+
+	crlCacheHitsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "crl_cache_hits_total",
+		Help: "Total number of CRL requests served from cache, per issuer.",
+	}, []string{"issuer"})
+
+	crlRevokedCertificates = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "crl_revoked_certificates",
+		Help: "Number of certificates in the current CRL, per issuer.",
+	}, []string{"issuer"})
+
+	crlCurrentNumber = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "crl_current_number",
+		Help: "CRL number of the most recently generated CRL, per issuer.",
+	}, []string{"issuer"})
+
+	crlNextUpdateTimestamp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "crl_next_update_timestamp_seconds",
+		Help: "Unix timestamp of the current CRL's nextUpdate, per issuer.",
+	}, []string{"issuer"})
+
+	crlLoadErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "crl_load_errors_total",
+		Help: "Total number of errors loading a CRL input, by issuer and source.",
+	}, []string{"issuer", "source"})
+)
+
+func bigIntToFloat64(n *big.Int) float64 {
+	f := new(big.Float).SetInt(n)
+	v, _ := f.Float64()
+	return v
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// ocspCrtFileEnv and ocspKeyFileEnv select a dedicated OCSP signing
+	// certificate/key, instead of reusing the CA's own, when set. Per RFC
+	// 6960 6.1.1, that certificate should itself carry the id-pkix-ocsp-nocheck
+	// extension if the operator wants relying parties to skip revocation
+	// checking on it; this server has no way to add the extension after the
+	// fact, since it only ever loads the certificate that was issued to it.
+	ocspCrtFileEnv = "OCSP_CRT_FILE"
+	ocspKeyFileEnv = "OCSP_KEY_FILE"
+
+	// maxOCSPRequestBytes bounds a POSTed application/ocsp-request body.
+	maxOCSPRequestBytes = 64 * 1024
+
+	// issuedSerialsFileName is the optional file, alongside a plain
+	// "list.txt" revocation list, enumerating every serial this CA has
+	// issued (one hex serial per line). It has no effect for an index.txt
+	// CA database, which already records every issued serial itself.
+	issuedSerialsFileName = "issued.txt"
+)
+
+// ocspCacheEntry is a cached, signed OCSP response for a single serial
+// number, valid until it is older than cacheDuration or the revocation list
+// is reloaded.
+type ocspCacheEntry struct {
+	response    []byte
+	generatedAt time.Time
+}
+
+// handleOCSP serves RFC 6960 OCSP requests, either GET (base64-encoded DER
+// in the path) or POST (application/ocsp-request body).
+func (s *CRLServer) handleOCSP(w http.ResponseWriter, r *http.Request) {
+	rawReq, err := readOCSPRequestBytes(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.getOCSPResponse(rawReq)
+	if err != nil {
+		log.Printf("Error generating OCSP response: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+func readOCSPRequestBytes(r *http.Request) ([]byte, error) {
+	switch r.Method {
+	case http.MethodGet:
+		_, encoded, found := strings.Cut(r.URL.Path, "/ocsp/")
+		if !found || encoded == "" {
+			return nil, fmt.Errorf("missing OCSP request in path")
+		}
+
+		if unescaped, err := url.PathUnescape(encoded); err == nil {
+			encoded = unescaped
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			raw, err = base64.RawStdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("decoding base64 OCSP request: %w", err)
+			}
+		}
+		return raw, nil
+
+	case http.MethodPost:
+		if ct := r.Header.Get("Content-Type"); ct != "application/ocsp-request" {
+			return nil, fmt.Errorf("unexpected Content-Type %q", ct)
+		}
+		return io.ReadAll(io.LimitReader(r.Body, maxOCSPRequestBytes))
+
+	default:
+		return nil, fmt.Errorf("unsupported method %q", r.Method)
+	}
+}
+
+// getOCSPResponse parses a DER OCSP request, looks the serial up in the
+// issuer's revocation list, and returns a signed OCSP response, serving a
+// cached one when available. On a cache miss the revocation list is re-read
+// from listFile; the revoked set held from the last CRL generation is not
+// consulted.
+func (s *CRLServer) getOCSPResponse(rawReq []byte) ([]byte, error) {
+	ocspReq, err := ocsp.ParseRequest(rawReq)
+	if err != nil {
+		return nil, fmt.Errorf("parsing OCSP request: %w", err)
+	}
+
+	serialKey := ocspReq.SerialNumber.String()
+
+	s.ocspMu.RLock()
+	if entry, ok := s.ocspCache[serialKey]; ok && time.Since(entry.generatedAt) < cacheDuration {
+		s.ocspMu.RUnlock()
+		return entry.response, nil
+	}
+	s.ocspMu.RUnlock()
+
+	caCert, caPrivKey, err := loadCertAndKey(s.crtFile, s.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading certificate and key: %w", err)
+	}
+
+	revokedCerts, err := s.loadRevokedCertificates()
+	if err != nil {
+		return nil, fmt.Errorf("loading revoked certificates: %w", err)
+	}
+
+	// issuedSerials is the set of serials this CA is known to have issued.
+	// Without it, we have no basis to affirm Good for an arbitrary serial,
+	// so the default is Unknown (RFC 6960 2.2) rather than Good.
+	issuedSerials, issuedKnown, err := s.loadIssuedSerials()
+	if err != nil {
+		return nil, fmt.Errorf("loading issued serial registry: %w", err)
+	}
+
+	now := time.Now()
+	template := ocsp.Response{
+		Status:       ocsp.Unknown,
+		SerialNumber: ocspReq.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(cacheDuration),
+	}
+
+	if issuedKnown && issuedSerials[ocspReq.SerialNumber.Text(16)] {
+		template.Status = ocsp.Good
+	}
+
+	for _, rc := range revokedCerts {
+		if rc.SerialNumber.Cmp(ocspReq.SerialNumber) == 0 {
+			template.Status = ocsp.Revoked
+			template.RevokedAt = rc.RevocationTime
+			template.RevocationReason = rc.Reason
+			break
+		}
+	}
+
+	responderCert := caCert
+	responderKey := caPrivKey
+	if s.ocspCrtFile != "" {
+		responderCert, err = loadCertificate(s.ocspCrtFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading OCSP responder certificate: %w", err)
+		}
+		responderKey, err = (&filePEMSigner{keyFile: s.ocspKeyFile}).Signer()
+		if err != nil {
+			return nil, fmt.Errorf("loading OCSP responder key: %w", err)
+		}
+		template.Certificate = responderCert
+	}
+
+	respBytes, err := ocsp.CreateResponse(caCert, responderCert, template, responderKey)
+	if err != nil {
+		return nil, fmt.Errorf("creating OCSP response: %w", err)
+	}
+
+	s.ocspMu.Lock()
+	s.ocspCache[serialKey] = ocspCacheEntry{response: respBytes, generatedAt: now}
+	s.ocspMu.Unlock()
+
+	return respBytes, nil
+}
+
+// invalidateOCSPCache drops all cached OCSP responses, called whenever the
+// revocation list is reloaded so stale statuses aren't served.
+func (s *CRLServer) invalidateOCSPCache() {
+	s.ocspMu.Lock()
+	s.ocspCache = make(map[string]ocspCacheEntry)
+	s.ocspMu.Unlock()
+}
+
+// loadIssuedSerials returns the set of serials this issuer is known to have
+// issued, and whether such a registry is available at all. For an index.txt
+// CA database the registry is the whole file, since every row - regardless
+// of status - represents an issued certificate. For a plain list.txt it's
+// the optional issuedSerialsFileName sitting beside it; if that file is
+// absent, known is false and getOCSPResponse reports Unknown rather than
+// guessing Good.
+func (s *CRLServer) loadIssuedSerials() (serials map[string]bool, known bool, err error) {
+	if detectRevocationFormat(s.listFile) == "index" {
+		serials, err = loadIndexTxtAllSerials(s.name, s.listFile)
+		if err != nil {
+			return nil, false, err
+		}
+		return serials, true, nil
+	}
+
+	issuedFile := filepath.Join(filepath.Dir(s.listFile), issuedSerialsFileName)
+	data, err := os.ReadFile(issuedFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("reading issued serials file: %w", err)
+	}
+
+	serials = make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		serial := new(big.Int)
+		if _, ok := serial.SetString(line, 16); !ok {
+			auditLog.Warn("invalid issued serial", "issuer", s.name, "raw", line)
+			continue
+		}
+		serials[serial.Text(16)] = true
+	}
+
+	return serials, true, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/x509"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestGetOCSPResponseStatuses(t *testing.T) {
+	s := newTestIssuer(t, map[string]int{"a": 1})
+
+	caCert, _, err := loadCertAndKey(s.crtFile, s.keyFile)
+	if err != nil {
+		t.Fatalf("loading CA cert: %v", err)
+	}
+
+	cases := []struct {
+		name       string
+		issuedFile string // content of issued.txt, if any
+		serial     string
+		want       int
+	}{
+		{
+			name:   "revoked serial is Revoked regardless of issued registry",
+			serial: "a",
+			want:   ocsp.Revoked,
+		},
+		{
+			name:   "non-revoked serial with no issued registry is Unknown",
+			serial: "ff",
+			want:   ocsp.Unknown,
+		},
+		{
+			name:       "non-revoked serial present in issued.txt is Good",
+			issuedFile: "a\nff\n",
+			serial:     "ff",
+			want:       ocsp.Good,
+		},
+		{
+			name:       "serial absent from issued.txt is still Unknown",
+			issuedFile: "a\n",
+			serial:     "ff",
+			want:       ocsp.Unknown,
+		},
+	}
+
+	issuedPath := filepath.Join(filepath.Dir(s.listFile), issuedSerialsFileName)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Remove(issuedPath)
+			if tc.issuedFile != "" {
+				if err := os.WriteFile(issuedPath, []byte(tc.issuedFile), 0644); err != nil {
+					t.Fatalf("writing issued.txt: %v", err)
+				}
+				defer os.Remove(issuedPath)
+			}
+			s.invalidateOCSPCache()
+
+			serial := new(big.Int)
+			serial.SetString(tc.serial, 16)
+
+			rawReq, err := ocsp.CreateRequest(&x509.Certificate{SerialNumber: serial}, caCert, nil)
+			if err != nil {
+				t.Fatalf("creating OCSP request: %v", err)
+			}
+
+			resp, err := s.getOCSPResponse(rawReq)
+			if err != nil {
+				t.Fatalf("getOCSPResponse: %v", err)
+			}
+
+			parsed, err := ocsp.ParseResponse(resp, nil)
+			if err != nil {
+				t.Fatalf("parsing OCSP response: %v", err)
+			}
+
+			if parsed.Status != tc.want {
+				t.Errorf("status = %d, want %d", parsed.Status, tc.want)
+			}
+		})
+	}
+}
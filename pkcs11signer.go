@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/ThalesIgnite/crypto11"
+)
+
+const (
+	pkcs11SlotEnv     = "PKCS11_SLOT"
+	pkcs11PinEnv      = "PKCS11_PIN"
+	pkcs11KeyLabelEnv = "PKCS11_KEY_LABEL"
+)
+
+// pkcs11Signer delegates the CRL-signing private key operation to a key held
+// in an HSM or smartcard via PKCS#11, so the CA private key material never
+// needs to touch disk. The CA certificate itself is still loaded from PEM.
+//
+// Configuring a PKCS#11 module opens a session pool against the HSM, so it
+// is done at most once (guarded by mu) and the resulting context and signer
+// are cached and reused across every loadCertAndKey call, rather than
+// reconfigured per CRL/OCSP/probe request.
+type pkcs11Signer struct {
+	module   string
+	slot     int
+	pin      string
+	keyLabel string
+
+	mu     sync.Mutex
+	ctx    *crypto11.Context
+	signer crypto.Signer
+}
+
+// pkcs11Once and pkcs11Instance memoize the single pkcs11Signer for the
+// process: PKCS11_* configuration is process-wide, not per-issuer, so every
+// issuer configured to use it shares one HSM session pool.
+var (
+	pkcs11Once     sync.Once
+	pkcs11Instance *pkcs11Signer
+	pkcs11InitErr  error
+)
+
+// newPKCS11SignerFromEnv returns the process-wide pkcs11Signer, building it
+// from the PKCS11_* env vars on first call: PKCS11_MODULE (the
+// already-resolved module path), PKCS11_SLOT, PKCS11_PIN, and
+// PKCS11_KEY_LABEL.
+func newPKCS11SignerFromEnv(module string) (*pkcs11Signer, error) {
+	pkcs11Once.Do(func() {
+		pkcs11Instance, pkcs11InitErr = buildPKCS11SignerFromEnv(module)
+	})
+	return pkcs11Instance, pkcs11InitErr
+}
+
+func buildPKCS11SignerFromEnv(module string) (*pkcs11Signer, error) {
+	slot, err := strconv.Atoi(os.Getenv(pkcs11SlotEnv))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", pkcs11SlotEnv, err)
+	}
+
+	keyLabel := os.Getenv(pkcs11KeyLabelEnv)
+	if keyLabel == "" {
+		return nil, fmt.Errorf("%s must be set when %s is configured", pkcs11KeyLabelEnv, pkcs11ModuleEnv)
+	}
+
+	return &pkcs11Signer{
+		module:   module,
+		slot:     slot,
+		pin:      os.Getenv(pkcs11PinEnv),
+		keyLabel: keyLabel,
+	}, nil
+}
+
+func (s *pkcs11Signer) Signer() (crypto.Signer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.signer != nil {
+		return s.signer, nil
+	}
+
+	slot := s.slot
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       s.module,
+		SlotNumber: &slot,
+		Pin:        s.pin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("configuring PKCS#11 module %q: %w", s.module, err)
+	}
+
+	signer, err := ctx.FindKeyPair(nil, []byte(s.keyLabel))
+	if err != nil {
+		ctx.Close()
+		return nil, fmt.Errorf("finding PKCS#11 key pair %q: %w", s.keyLabel, err)
+	}
+	if signer == nil {
+		ctx.Close()
+		return nil, fmt.Errorf("no PKCS#11 key pair found with label %q", s.keyLabel)
+	}
+
+	s.ctx = ctx
+	s.signer = signer
+	return signer, nil
+}
+
+// Close releases the PKCS#11 session pool opened by Signer, if any, so a
+// graceful shutdown doesn't leak HSM sessions.
+func (s *pkcs11Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx == nil {
+		return nil
+	}
+	err := s.ctx.Close()
+	s.ctx = nil
+	s.signer = nil
+	return err
+}
+
+// closeSigners releases any process-wide signer resources (currently just
+// the PKCS#11 session pool, if one was configured) so a graceful shutdown
+// doesn't leak HSM sessions.
+func closeSigners() {
+	if pkcs11Instance != nil {
+		if err := pkcs11Instance.Close(); err != nil {
+			log.Printf("Warning: failed to close PKCS#11 signer: %v", err)
+		}
+	}
+}
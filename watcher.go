@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// startFileWatcher watches an issuer's certificate, key, and revocation
+// list files and regenerates its CRL immediately when any of them change,
+// rather than waiting for a request after the cache has expired.
+func startFileWatcher(s *CRLServer) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Warning: failed to start file watcher for issuer %q: %v", s.name, err)
+		return
+	}
+
+	watched := map[string]bool{
+		filepath.Clean(s.crtFile):  true,
+		filepath.Clean(s.keyFile):  true,
+		filepath.Clean(s.listFile): true,
+	}
+
+	// Watch the containing directories rather than the files themselves:
+	// ConfigMap/Secret updates typically replace files via rename, which
+	// would otherwise orphan a watch held on the old inode.
+	dirs := make(map[string]bool)
+	for path := range watched {
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			log.Printf("Warning: failed to watch %s for issuer %q: %v", dir, s.name, err)
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !watched[filepath.Clean(event.Name)] {
+					continue
+				}
+				log.Printf("Detected change to %s for issuer %q, regenerating CRL", event.Name, s.name)
+				if _, err := s.regenerateCRL(); err != nil {
+					log.Printf("Error regenerating CRL for issuer %q: %v", s.name, err)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("Watcher error for issuer %q: %v", s.name, err)
+			}
+		}
+	}()
+}
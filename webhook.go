@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+)
+
+// revocationWebhookURLEnv, when set, is POSTed a JSON payload whenever an
+// issuer's revoked set changes, so relying parties don't need to poll.
+const revocationWebhookURLEnv = "REVOCATION_WEBHOOK_URL"
+
+// revocationWebhookTimeout bounds how long notifyRevocationWebhook waits
+// for the downstream system to accept the notification.
+const revocationWebhookTimeout = 10 * time.Second
+
+type revocationWebhookPayload struct {
+	Issuer    string   `json:"issuer"`
+	CRLNumber string   `json:"crlNumber"`
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+}
+
+// notifyRevocationWebhook POSTs the serials added to and removed from
+// issuer's revoked set since the previous generation to
+// REVOCATION_WEBHOOK_URL, if configured. Intended to run in its own
+// goroutine; failures are logged, not returned, so they never affect CRL
+// generation.
+func notifyRevocationWebhook(issuer string, crlNumber *big.Int, added, removed []RevokedCert) {
+	url := os.Getenv(revocationWebhookURLEnv)
+	if url == "" {
+		return
+	}
+
+	payload := revocationWebhookPayload{
+		Issuer:    issuer,
+		CRLNumber: crlNumber.String(),
+		Added:     serialStrings(added),
+		Removed:   serialStrings(removed),
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Warning: failed to marshal revocation webhook payload for issuer %q: %v", issuer, err)
+		return
+	}
+
+	client := &http.Client{Timeout: revocationWebhookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Warning: failed to deliver revocation webhook for issuer %q: %v", issuer, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Warning: revocation webhook for issuer %q returned status %s", issuer, resp.Status)
+	}
+}
+
+func serialStrings(certs []RevokedCert) []string {
+	serials := make([]string, 0, len(certs))
+	for _, rc := range certs {
+		serials = append(serials, fmt.Sprintf("%x", rc.SerialNumber))
+	}
+	return serials
+}